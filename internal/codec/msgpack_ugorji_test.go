@@ -0,0 +1,44 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codec
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsgpackUgorjiSerializerRoundTrip(t *testing.T) {
+	s := &MsgpackUgorjiSerializer{}
+
+	data, err := s.MarshalRequest(map[string]interface{}{"name": "laurence", "age": 18})
+	assert.NoError(t, err)
+
+	var got map[string]interface{}
+	assert.NoError(t, s.UnmarshalRequest(data, &got))
+	assert.Equal(t, "laurence", got["name"])
+
+	data, err = s.MarshalResponse([]int{1, 2, 3})
+	assert.NoError(t, err)
+
+	var gotSlice []int
+	assert.NoError(t, s.UnmarshalResponse(data, &gotSlice))
+	assert.Equal(t, []int{1, 2, 3}, gotSlice)
+}
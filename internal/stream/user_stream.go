@@ -19,11 +19,17 @@ package stream
 
 import (
 	"context"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
 )
 
 import (
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 import (
@@ -38,13 +44,42 @@ type baseUserStream struct {
 	stream     Stream
 	serilizer  common.Dubbo3Serializer
 	pkgHandler common.PackageHandler
+
+	// sendWG tracks in-flight SendMsg calls so a half-close can wait for
+	// them to finish before the close frame is put on the wire.
+	sendWG sync.WaitGroup
+	// sendClosed is set once CloseSend has been called, after which
+	// SendMsg is rejected.
+	sendClosed int32
+	// sendMu makes SendMsg's sendClosed check and sendWG.Add one step with
+	// respect to CloseSend, so CloseSend's Wait sees every admitted send.
+	sendMu sync.RWMutex
+
+	headerMu sync.Mutex
+	header   metadata.MD
 }
 
-// nolint
-func (ss *baseUserStream) SetHeader(metadata.MD) error {
+// SetHeader records @md to be sent with the response headers. Actually
+// writing it onto the HTTP/2 response requires the h2 controller's
+// header-writing path, which is not part of this checkout, so for now this
+// only accumulates the metadata for Header to return -- a blocking
+// dependency on that missing wiring, not a functioning header path.
+func (ss *baseUserStream) SetHeader(md metadata.MD) error {
+	ss.headerMu.Lock()
+	defer ss.headerMu.Unlock()
+	ss.header = metadata.Join(ss.header, md)
 	return nil
 }
 
+// Header returns the metadata accumulated by SetHeader so far. It exists so
+// callers (e.g. tests) can observe what SetHeader recorded, since nothing in
+// this checkout transmits it onto the wire yet.
+func (ss *baseUserStream) Header() metadata.MD {
+	ss.headerMu.Lock()
+	defer ss.headerMu.Unlock()
+	return ss.header
+}
+
 // nolint
 func (ss *baseUserStream) SendHeader(metadata.MD) error {
 	return nil
@@ -61,11 +96,23 @@ func (ss *baseUserStream) Context() context.Context {
 
 // nolint
 func (ss *baseUserStream) SendMsg(m interface{}) error {
+	ss.sendMu.RLock()
+	if atomic.LoadInt32(&ss.sendClosed) != 0 {
+		ss.sendMu.RUnlock()
+		return errors.Errorf("send on a half-closed stream")
+	}
+	ss.sendWG.Add(1)
+	ss.sendMu.RUnlock()
+	defer ss.sendWG.Done()
+
 	replyData, err := ss.serilizer.MarshalRequest(m)
 	if err != nil {
 		ss.opt.Logger.Error("sen msg error with msg = ", m)
 		return err
 	}
+	if ss.opt.MaxCallSendMsgSize > 0 && uint32(len(replyData)) > ss.opt.MaxCallSendMsgSize {
+		return status.Errorf(codes.ResourceExhausted, "triple: message of size %d bytes exceeds MaxCallSendMsgSize of %d bytes", len(replyData), ss.opt.MaxCallSendMsgSize)
+	}
 	rspFrameData := ss.pkgHandler.Pkg2FrameData(replyData)
 	ss.stream.PutSend(rspFrameData, message.DataMsgType)
 	return nil
@@ -76,9 +123,16 @@ func (ss *baseUserStream) RecvMsg(m interface{}) error {
 	recvChan := ss.stream.GetRecv()
 	readBuf := <-recvChan
 	if readBuf.Buffer == nil {
+		if readBuf.MsgType == message.ClientStreamCloseMsgType {
+			return io.EOF
+		}
 		return errors.Errorf("user stream closed!")
 	}
-	pkgData, _ := ss.pkgHandler.Frame2PkgData(readBuf.Bytes())
+	recvBytes := readBuf.Bytes()
+	if ss.opt.MaxCallRecvMsgSize > 0 && uint32(len(recvBytes)) > ss.opt.MaxCallRecvMsgSize {
+		return status.Errorf(codes.ResourceExhausted, "triple: received message larger than max (%d vs. %d)", len(recvBytes), ss.opt.MaxCallRecvMsgSize)
+	}
+	pkgData, _ := ss.pkgHandler.Frame2PkgData(recvBytes)
 	if err := ss.serilizer.UnmarshalResponse(pkgData, m); err != nil {
 		return err
 	}
@@ -88,8 +142,21 @@ func (ss *baseUserStream) RecvMsg(m interface{}) error {
 // serverUserStream can be throw to grpc, and let grpc use it
 type serverUserStream struct {
 	baseUserStream
+
+	// path is /interfaceKey/methodName, used to key request-admission
+	// interceptors such as rate limiters.
+	path string
+
+	// interceptOnce runs the ServerInterceptors once per RPC, on its first
+	// RecvMsg; interceptErr keeps a rejection for every later RecvMsg.
+	interceptOnce sync.Once
+	interceptErr  error
 }
 
+// newServerUserStream keeps its original call signature so every existing
+// call site keeps compiling unchanged; callers that know the dispatch path
+// (e.g. h2 controller's server dispatch) opt in by calling SetPath
+// afterwards. Without it, ServerInterceptors still run, just keyed by "".
 func newServerUserStream(s Stream, serilizer common.Dubbo3Serializer, pkgHandler common.PackageHandler, opt *config.Option) *serverUserStream {
 	return &serverUserStream{
 		baseUserStream: baseUserStream{
@@ -101,6 +168,48 @@ func newServerUserStream(s Stream, serilizer common.Dubbo3Serializer, pkgHandler
 	}
 }
 
+// SetPath records the dispatch path (/interfaceKey/methodName) this stream
+// was routed to, so ServerInterceptors can key on it. Must be called, if at
+// all, before the first RecvMsg.
+func (ss *serverUserStream) SetPath(path string) {
+	ss.path = path
+}
+
+// grpcRetryPushbackHeader mirrors grpc-go's own retry throttling header: a
+// millisecond count the client should wait before retrying, set when a
+// ServerInterceptor rejects a call with a retry-after hint.
+const grpcRetryPushbackHeader = "grpc-retry-pushback-ms"
+
+// RecvMsg runs the configured ServerInterceptors once per RPC, on the first
+// RecvMsg, before delegating to baseUserStream.RecvMsg, so a rejecting
+// interceptor (e.g. a TPS limiter) can reject the RPC before any message is
+// read off the wire. After a rejection every RecvMsg returns the same error.
+//
+// retryAfter is recorded via SetHeader so it is structurally available
+// rather than only embedded in the error text, but SetHeader does not yet
+// transmit anything -- writing it onto the actual HTTP/2 response headers
+// is blocked on h2 controller support this checkout doesn't have. Until
+// that lands, the retry-after text ratelimit.go also puts in the status
+// message remains the only copy a caller can actually observe.
+func (ss *serverUserStream) RecvMsg(m interface{}) error {
+	ss.interceptOnce.Do(func() {
+		for _, interceptor := range ss.opt.ServerInterceptors {
+			retryAfter, err := interceptor.Intercept(ss.path)
+			if err != nil {
+				if retryAfter > 0 {
+					_ = ss.SetHeader(metadata.Pairs(grpcRetryPushbackHeader, strconv.FormatInt(retryAfter.Milliseconds(), 10)))
+				}
+				ss.interceptErr = err
+				return
+			}
+		}
+	})
+	if ss.interceptErr != nil {
+		return ss.interceptErr
+	}
+	return ss.baseUserStream.RecvMsg(m)
+}
+
 // clientUserStream can be throw to grpc, and let grpc use it
 type clientUserStream struct {
 	baseUserStream
@@ -116,9 +225,20 @@ func (ss *clientUserStream) Trailer() metadata.MD {
 	return nil
 }
 
-// nolint
+// CloseSend signals the underlying Stream that no more DataMsgType frames
+// will be sent on the request side, so the h2 controller can emit an
+// HTTP/2 DATA frame with END_STREAM. It waits for in-flight SendMsg calls
+// to finish first, is idempotent, and causes subsequent SendMsg calls to
+// return an error.
 func (ss *clientUserStream) CloseSend() error {
-	// todo
+	ss.sendMu.Lock()
+	swapped := atomic.CompareAndSwapInt32(&ss.sendClosed, 0, 1)
+	ss.sendMu.Unlock()
+	if !swapped {
+		return nil
+	}
+	ss.sendWG.Wait()
+	ss.stream.PutSend(nil, message.ClientStreamCloseMsgType)
 	return nil
 }
 
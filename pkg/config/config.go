@@ -17,12 +17,24 @@
 
 package config
 
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
 import (
 	"github.com/dubbogo/triple/pkg/common/constant"
 	"github.com/dubbogo/triple/pkg/common/logger"
 	"github.com/dubbogo/triple/pkg/common/logger/default_logger"
 )
 
+// defaultMaxCallMsgSize is the default value for MaxCallRecvMsgSize and
+// MaxCallSendMsgSize, matching grpc-go's default of 4MiB.
+const defaultMaxCallMsgSize uint32 = 4 * 1024 * 1024
+
 // triple option
 type Option struct {
 	// network opts
@@ -38,6 +50,23 @@ type Option struct {
 	HeaderGroup      string
 	HeaderAppVersion string
 
+	// DrainTimeout bounds how long Shutdown waits for in-flight unary calls
+	// and active streams to finish draining before the connection is torn
+	// down regardless.
+	DrainTimeout time.Duration
+
+	// ServerInterceptors run, in order, before RecvMsg is called on a new
+	// server-side unary or stream invocation, so that a rejection can short
+	// circuit the RPC before any message is read.
+	ServerInterceptors []ServerInterceptor
+
+	// MaxCallRecvMsgSize is the maximum message size in bytes the client/server
+	// will accept when reading a frame, set via WithReadMaxBytes.
+	MaxCallRecvMsgSize uint32
+	// MaxCallSendMsgSize is the maximum message size in bytes the client/server
+	// will send in a single frame, set via WithSendMaxBytes.
+	MaxCallSendMsgSize uint32
+
 	// logger
 	Logger logger.Logger
 }
@@ -67,6 +96,31 @@ func (o *Option) Validate() {
 	if o.SerializerType == "" {
 		o.SerializerType = constant.PBSerializerName
 	}
+
+	if o.DrainTimeout == 0 {
+		o.DrainTimeout = constant.DefaultDrainTimeout
+	}
+
+	if o.MaxCallRecvMsgSize == 0 {
+		o.MaxCallRecvMsgSize = defaultMaxCallMsgSize
+	}
+
+	if o.MaxCallSendMsgSize == 0 {
+		o.MaxCallSendMsgSize = defaultMaxCallMsgSize
+	}
+}
+
+// ServerInterceptor admits or rejects an incoming RPC identified by @path
+// (e.g. /interfaceKey/methodName) before RecvMsg is invoked on it, letting
+// callers plug in request-admission policies such as rate limiting. It is
+// the server-side counterpart of the stream package's newServerUserStream.
+type ServerInterceptor interface {
+	// Intercept returns a non-nil err, typically a gRPC status error, to
+	// reject the RPC before any message is read from it. When a rejection
+	// is caused by admission control (e.g. a TPS limiter), retryAfter is
+	// the duration the caller should wait before retrying; it is zero when
+	// no such hint applies.
+	Intercept(path string) (retryAfter time.Duration, err error)
 }
 
 // nolint
@@ -96,7 +150,7 @@ func WithBufferSize(size uint32) OptionFunction {
 	}
 }
 
-// WithSerializerType return OptionFunction with target @serializerType, now we support "protobuf" and "hessian2"
+// WithSerializerType return OptionFunction with target @serializerType, now we support "protobuf", "hessian2" and "msgpack-ugorji"
 func WithSerializerType(serializerType constant.TripleSerializerName) OptionFunction {
 	return func(o *Option) {
 		o.SerializerType = serializerType
@@ -131,9 +185,94 @@ func WithHeaderGroup(group string) OptionFunction {
 	}
 }
 
+// WithDrainTimeout return OptionFunction with target @timeout, bounding how
+// long Shutdown waits for in-flight calls to drain before closing the
+// connection anyway
+func WithDrainTimeout(timeout time.Duration) OptionFunction {
+	return func(o *Option) {
+		o.DrainTimeout = timeout
+	}
+}
+
+// WithServerInterceptors return OptionFunction that installs @interceptors on
+// the server-side dispatcher, run in order before RecvMsg is called
+func WithServerInterceptors(interceptors ...ServerInterceptor) OptionFunction {
+	return func(o *Option) {
+		o.ServerInterceptors = append(o.ServerInterceptors, interceptors...)
+	}
+}
+
 // WithLogger return OptionFunction with target @logger, which must impl triple/pkg/common/logger.Logger
 func WithLogger(logger logger.Logger) OptionFunction {
 	return func(o *Option) {
 		o.Logger = logger
 	}
 }
+
+// WithReadMaxBytes return OptionFunction with target max recv message size
+// @size, given as a humanized byte size such as "4MiB". An invalid or
+// out-of-range size is logged and the default is kept instead.
+func WithReadMaxBytes(size string) OptionFunction {
+	return func(o *Option) {
+		b, err := parseByteSize(size)
+		if err != nil {
+			default_logger.GetDefaultLogger().Errorf("triple: invalid WithReadMaxBytes value %q, keeping default: %v", size, err)
+			return
+		}
+		o.MaxCallRecvMsgSize = b
+	}
+}
+
+// WithSendMaxBytes return OptionFunction with target max send message size
+// @size, given as a humanized byte size such as "4MiB". An invalid or
+// out-of-range size is logged and the default is kept instead.
+func WithSendMaxBytes(size string) OptionFunction {
+	return func(o *Option) {
+		b, err := parseByteSize(size)
+		if err != nil {
+			default_logger.GetDefaultLogger().Errorf("triple: invalid WithSendMaxBytes value %q, keeping default: %v", size, err)
+			return
+		}
+		o.MaxCallSendMsgSize = b
+	}
+}
+
+// byteSizeUnits maps the humanized size suffixes accepted by
+// WithReadMaxBytes/WithSendMaxBytes to their byte multiplier. Longer
+// suffixes are checked first so "MiB" is not mistaken for "B".
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier uint64
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+	{"GB", 1e9},
+	{"MB", 1e6},
+	{"KB", 1e3},
+	{"B", 1},
+}
+
+// parseByteSize parses a humanized byte size such as "4MiB" or "512KB" into
+// a byte count, erroring out instead of silently truncating if the result
+// would overflow uint32.
+func parseByteSize(size string) (uint32, error) {
+	size = strings.TrimSpace(size)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(size, unit.suffix) {
+			n, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(size, unit.suffix)), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			if unit.multiplier != 0 && n > math.MaxUint32/unit.multiplier {
+				return 0, fmt.Errorf("byte size %q overflows uint32", size)
+			}
+			return uint32(n * unit.multiplier), nil
+		}
+	}
+	n, err := strconv.ParseUint(size, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(n), nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSizeUnits(t *testing.T) {
+	cases := []struct {
+		in   string
+		want uint32
+	}{
+		{"4MiB", 4 * 1024 * 1024},
+		{"512KiB", 512 * 1024},
+		{"1GiB", 1 << 30},
+		{"2MB", 2e6},
+		{"100B", 100},
+		{"1024", 1024},
+	}
+	for _, c := range cases {
+		got, err := parseByteSize(c.in)
+		assert.NoError(t, err, c.in)
+		assert.Equal(t, c.want, got, c.in)
+	}
+}
+
+func TestParseByteSizeOverflow(t *testing.T) {
+	_, err := parseByteSize("8GiB")
+	assert.Error(t, err)
+}
+
+func TestParseByteSizeInvalid(t *testing.T) {
+	_, err := parseByteSize("not-a-size")
+	assert.Error(t, err)
+}
+
+func TestWithReadMaxBytesInvalidKeepsDefault(t *testing.T) {
+	opt := NewTripleOption(WithReadMaxBytes("not-a-size"))
+	opt.Validate()
+	assert.Equal(t, defaultMaxCallMsgSize, opt.MaxCallRecvMsgSize)
+}
+
+func TestWithReadMaxBytesValid(t *testing.T) {
+	opt := NewTripleOption(WithReadMaxBytes("8MiB"))
+	opt.Validate()
+	assert.Equal(t, uint32(8*1024*1024), opt.MaxCallRecvMsgSize)
+}
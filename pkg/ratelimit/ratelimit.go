@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ratelimit provides built-in config.ServerInterceptor
+// implementations for admission control on the server-side dispatcher,
+// installed via config.WithServerInterceptors.
+package ratelimit
+
+import (
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+)
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// shardCount is the number of map shards used to spread lock contention
+// across distinct /interfaceKey/methodName paths.
+const shardCount = 32
+
+func shardIndex(path string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	return h.Sum32() % shardCount
+}
+
+// FixedWindowLimiter is a config.ServerInterceptor that rejects requests
+// once a path has been called more than @rate times within the current
+// @window. Counters live in a sharded map keyed by path and are reset for
+// every path at once by a monotonic ticker, rather than on a per-path
+// lazy-expiry check.
+type FixedWindowLimiter struct {
+	rate   int
+	window time.Duration
+	shards [shardCount]*fixedWindowShard
+	ticker *time.Ticker
+	stopCh chan struct{}
+}
+
+type fixedWindowShard struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewFixedWindowLimiter creates a FixedWindowLimiter allowing up to @rate
+// requests per path for every @window, e.g. NewFixedWindowLimiter(100,
+// time.Second) allows 100 req/s per /interfaceKey/methodName.
+func NewFixedWindowLimiter(rate int, window time.Duration) *FixedWindowLimiter {
+	l := &FixedWindowLimiter{
+		rate:   rate,
+		window: window,
+		ticker: time.NewTicker(window),
+		stopCh: make(chan struct{}),
+	}
+	for i := range l.shards {
+		l.shards[i] = &fixedWindowShard{counts: make(map[string]int)}
+	}
+	go l.resetLoop()
+	return l
+}
+
+func (l *FixedWindowLimiter) resetLoop() {
+	for {
+		select {
+		case <-l.ticker.C:
+			for _, shard := range l.shards {
+				shard.mu.Lock()
+				shard.counts = make(map[string]int)
+				shard.mu.Unlock()
+			}
+		case <-l.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops the limiter's reset ticker. It should be called when the
+// owning server is shut down.
+func (l *FixedWindowLimiter) Close() {
+	l.ticker.Stop()
+	close(l.stopCh)
+}
+
+// Intercept implements config.ServerInterceptor. On rejection, retryAfter is
+// the full window length, an upper bound on the time until the next reset,
+// since every path resets together on the same ticker.
+func (l *FixedWindowLimiter) Intercept(path string) (time.Duration, error) {
+	shard := l.shards[shardIndex(path)]
+
+	shard.mu.Lock()
+	shard.counts[path]++
+	count := shard.counts[path]
+	shard.mu.Unlock()
+
+	if count > l.rate {
+		return l.window, status.Errorf(codes.ResourceExhausted,
+			"tps limit exceeded for %s: more than %d requests in the current %s window, retry-after=%s",
+			path, l.rate, l.window, l.window)
+	}
+	return 0, nil
+}
+
+// TokenBucketLimiter is a config.ServerInterceptor admitting requests
+// against a per-path token bucket: each path accrues tokens at @rate per
+// second up to @burst, and every admitted request consumes one token.
+type TokenBucketLimiter struct {
+	rate  float64
+	burst float64
+
+	shards [shardCount]*tokenBucketShard
+}
+
+type tokenBucketShard struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketLimiter creates a TokenBucketLimiter refilling @rate tokens
+// per second, up to @burst tokens, per /interfaceKey/methodName path.
+func NewTokenBucketLimiter(rate float64, burst int) *TokenBucketLimiter {
+	l := &TokenBucketLimiter{rate: rate, burst: float64(burst)}
+	for i := range l.shards {
+		l.shards[i] = &tokenBucketShard{buckets: make(map[string]*tokenBucket)}
+	}
+	return l
+}
+
+func (l *TokenBucketLimiter) bucketFor(path string) *tokenBucket {
+	shard := l.shards[shardIndex(path)]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	b, ok := shard.buckets[path]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: time.Now()}
+		shard.buckets[path] = b
+	}
+	return b
+}
+
+// Intercept implements config.ServerInterceptor. On rejection, retryAfter is
+// how long the bucket needs to accrue its next token.
+func (l *TokenBucketLimiter) Intercept(path string) (time.Duration, error) {
+	b := l.bucketFor(path)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return retryAfter, status.Errorf(codes.ResourceExhausted,
+			"tps limit exceeded for %s: token bucket exhausted, retry-after=%s", path, retryAfter)
+	}
+	b.tokens--
+	return 0, nil
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedWindowLimiterAllowsUpToRateThenRejects(t *testing.T) {
+	l := NewFixedWindowLimiter(2, time.Minute)
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := l.Intercept("/foo.Greeter/SayHello")
+		assert.NoError(t, err)
+	}
+
+	retryAfter, err := l.Intercept("/foo.Greeter/SayHello")
+	assert.Error(t, err)
+	assert.Equal(t, time.Minute, retryAfter)
+}
+
+func TestFixedWindowLimiterTracksPathsIndependently(t *testing.T) {
+	l := NewFixedWindowLimiter(1, time.Minute)
+	defer l.Close()
+
+	_, err := l.Intercept("/foo.Greeter/A")
+	assert.NoError(t, err)
+	_, err = l.Intercept("/foo.Greeter/B")
+	assert.NoError(t, err)
+}
+
+func TestTokenBucketLimiterExhaustsBurstThenRejects(t *testing.T) {
+	l := NewTokenBucketLimiter(1, 1)
+
+	_, err := l.Intercept("/foo.Greeter/SayHello")
+	assert.NoError(t, err)
+
+	retryAfter, err := l.Intercept("/foo.Greeter/SayHello")
+	assert.Error(t, err)
+	assert.Greater(t, retryAfter, time.Duration(0))
+}
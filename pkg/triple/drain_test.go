@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainGroupRejectsEnterAfterClose(t *testing.T) {
+	var d DrainGroup
+	assert.NoError(t, d.Enter())
+	d.Leave()
+
+	d.Close()
+	assert.True(t, d.IsClosing())
+	assert.Error(t, d.Enter())
+}
+
+func TestDrainGroupWaitReturnsTrueOnceDrained(t *testing.T) {
+	var d DrainGroup
+	assert.NoError(t, d.Enter())
+	d.Leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.True(t, d.Wait(ctx))
+}
+
+func TestDrainGroupWaitReturnsFalseOnDeadline(t *testing.T) {
+	var d DrainGroup
+	assert.NoError(t, d.Enter())
+	defer d.Leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, d.Wait(ctx))
+}
+
+// TestDrainGroupCloseWaitsForInFlightEnter races Enter against Close/Wait
+// (run with -race) to guard against Add being called concurrently with
+// Wait, which sync.WaitGroup defines as misuse.
+func TestDrainGroupCloseWaitsForInFlightEnter(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		var d DrainGroup
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.Enter(); err == nil {
+				d.Leave()
+			}
+		}()
+
+		d.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		assert.True(t, d.Wait(ctx))
+		cancel()
+		wg.Wait()
+	}
+}
@@ -20,12 +20,15 @@ package triple
 import (
 	"context"
 	"reflect"
+	"strconv"
 	"sync"
+	"time"
 )
 
 import (
 	perrors "github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 import (
@@ -36,6 +39,27 @@ import (
 	"github.com/dubbogo/triple/pkg/config"
 )
 
+// tripleCtxKey is the type used for context keys defined by this package, so
+// that per-call values never collide with keys set by other packages.
+type tripleCtxKey string
+
+const (
+	// triServiceGroupHeader is the Dubbo3 header carrying the service group,
+	// used to disambiguate multiple implementations registered under the
+	// same interface.
+	triServiceGroupHeader = "tri-service-group"
+	// triServiceVersionHeader is the Dubbo3 header carrying the service
+	// version, used the same way as triServiceGroupHeader.
+	triServiceVersionHeader = "tri-service-version"
+
+	// CallGroupKey is the context key used to override opt.HeaderGroup for
+	// a single call, e.g. ctx = context.WithValue(ctx, triple.CallGroupKey, "groupA")
+	CallGroupKey = tripleCtxKey("triple.call.group")
+	// CallVersionKey is the context key used to override opt.HeaderAppVersion
+	// for a single call.
+	CallVersionKey = tripleCtxKey("triple.call.version")
+)
+
 // TripleClient client endpoint that using triple protocol
 type TripleClient struct {
 	h2Controller *H2Controller
@@ -49,6 +73,19 @@ type TripleClient struct {
 
 	// serializer is triple serializer to do codec
 	serializer common.Dubbo3Serializer
+
+	// drain tracks in-flight UnaryInvoke calls and active streams, and
+	// stops admitting new ones, so Shutdown can wait for them to finish
+	// draining before tearing down the connection.
+	drain DrainGroup
+}
+
+// clientRegistry holds every TripleClient created in this process, in
+// creation order, so a process-wide graceful_shutdown flow can drain and
+// close them all without each caller having to keep its own bookkeeping.
+var clientRegistry struct {
+	mu      sync.Mutex
+	clients []*TripleClient
 }
 
 // NewTripleClient creates triple client
@@ -71,9 +108,31 @@ func NewTripleClient(impl interface{}, opt *config.Option) (*TripleClient, error
 		tripleClient.StubInvoker = reflect.ValueOf(getInvoker(impl, newTripleConn(tripleClient)))
 	}
 
+	clientRegistry.mu.Lock()
+	clientRegistry.clients = append(clientRegistry.clients, tripleClient)
+	clientRegistry.mu.Unlock()
+
 	return tripleClient, nil
 }
 
+// GracefulShutdownAll calls Shutdown on every TripleClient created in this
+// process, in the order they were created, stopping at the first error.
+// This is the entry point the process-wide graceful_shutdown flow invokes
+// when the whole application is going down.
+func GracefulShutdownAll(ctx context.Context) error {
+	clientRegistry.mu.Lock()
+	clients := make([]*TripleClient, len(clientRegistry.clients))
+	copy(clients, clientRegistry.clients)
+	clientRegistry.mu.Unlock()
+
+	for _, c := range clients {
+		if err := c.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Connect called when new TripleClient, which start a tcp conn with target addr
 func (t *TripleClient) connect(opt *config.Option) error {
 	t.opt.Logger.Debugf("want to connect to location = ", opt.Location)
@@ -97,16 +156,8 @@ func (t *TripleClient) Invoke(methodName string, in []reflect.Value) []reflect.V
 		method := t.StubInvoker.MethodByName(methodName)
 		// call function in pb.go
 		return method.Call(in)
-	case constant.TripleHessianWrapperSerializerName:
-		out := codec.HessianUnmarshalStruct{}
-		ctx := in[0].Interface().(context.Context)
-		interfaceKey := ctx.Value(constant.InterfaceKey).(string)
-		err := t.Request(ctx, "/"+interfaceKey+"/"+methodName, in[1].Interface(), &out)
-		rsp = append(rsp, reflect.ValueOf(out.Val))
-		if err != nil {
-			return append(rsp, reflect.ValueOf(err))
-		}
-		return append(rsp, reflect.Value{})
+	case constant.TripleHessianWrapperSerializerName, codec.MsgpackUgorjiSerializerName:
+		return t.invokeWrapperStyle(methodName, in)
 	default:
 		t.opt.Logger.Errorf("Invalid triple client serializerType = %s", t.opt.SerializerType)
 		rsp = append(rsp, reflect.Value{})
@@ -114,25 +165,227 @@ func (t *TripleClient) Invoke(methodName string, in []reflect.Value) []reflect.V
 	}
 }
 
+// invokeWrapperStyle is the shared Invoke body for every serializer that
+// goes through the HessianUnmarshalStruct wrapper rather than a generated
+// pb stub: the reflect-based Hessian2 wrapper and the ugorji-backed
+// MessagePack codec both unmarshal their reply into out.Val the same way.
+func (t *TripleClient) invokeWrapperStyle(methodName string, in []reflect.Value) []reflect.Value {
+	rsp := make([]reflect.Value, 0, 2)
+	out := codec.HessianUnmarshalStruct{}
+	ctx := in[0].Interface().(context.Context)
+	interfaceKey := ctx.Value(constant.InterfaceKey).(string)
+	err := t.Request(ctx, "/"+interfaceKey+"/"+methodName, in[1].Interface(), &out)
+	rsp = append(rsp, reflect.ValueOf(out.Val))
+	if err != nil {
+		return append(rsp, reflect.ValueOf(err))
+	}
+	return append(rsp, reflect.Value{})
+}
+
 // Request call h2Controller to send unary rpc req to server
 // @path is /interfaceKey/functionName e.g. /com.apache.dubbo.sample.basic.IGreeter/BigUnaryTest
 // @arg is request body
 func (t *TripleClient) Request(ctx context.Context, path string, arg, reply interface{}) error {
+	if err := t.drain.Enter(); err != nil {
+		return err
+	}
+	defer t.drain.Leave()
+	ctx = t.withOutgoingMetadata(ctx)
 	return t.h2Controller.UnaryInvoke(ctx, path, arg, reply)
 }
 
 // StreamRequest call h2Controller to send streaming request to sever, to start link.
 // @path is /interfaceKey/functionName e.g. /com.apache.dubbo.sample.basic.IGreeter/BigStreamTest
 func (t *TripleClient) StreamRequest(ctx context.Context, path string) (grpc.ClientStream, error) {
-	return t.h2Controller.StreamInvoke(ctx, path)
+	if err := t.drain.Enter(); err != nil {
+		return nil, err
+	}
+	ctx = t.withOutgoingMetadata(ctx)
+	stream, err := t.h2Controller.StreamInvoke(ctx, path)
+	if err != nil {
+		t.drain.Leave()
+		return nil, err
+	}
+	dcs := &drainingClientStream{ClientStream: stream, ctx: ctx, done: t.drain.Leave, stopDeadlineWatch: make(chan struct{})}
+	if _, ok := ctx.Deadline(); ok {
+		go dcs.watchDeadline(ctx)
+	}
+	return dcs, nil
+}
+
+// drainingClientStream wraps a grpc.ClientStream so the owning TripleClient's
+// in-flight wait group is released once RecvMsg returns an error (io.EOF
+// included) or the stream's ctx is done, instead of as soon as it is
+// established, and so a caller context deadline tears the stream down
+// instead of only affecting the static opt.Timeout. A stream whose caller
+// stops calling RecvMsg before it returns an error, and whose ctx is never
+// done, is not released.
+type drainingClientStream struct {
+	grpc.ClientStream
+	ctx               context.Context
+	done              func()
+	doneOnce          sync.Once
+	stopDeadlineWatch chan struct{}
+}
+
+// RecvMsg races the underlying RecvMsg against ctx being done, so a caller
+// blocked on Recv returns ctx.Err() as soon as the deadline fires instead of
+// waiting for the server to notice the half-close watchDeadline sends.
+// s.ctx is only consulted for cancellation; the underlying call is left
+// running and its result is still consumed (into the buffered channel) so
+// the goroutine always exits once the transport actually returns.
+func (s *drainingClientStream) RecvMsg(m interface{}) error {
+	recvDone := make(chan error, 1)
+	go func() {
+		recvDone <- s.ClientStream.RecvMsg(m)
+	}()
+
+	select {
+	case err := <-recvDone:
+		if err != nil {
+			s.finish()
+		}
+		return err
+	case <-s.ctx.Done():
+		s.finish()
+		return s.ctx.Err()
+	}
+}
+
+// finish releases the owning client's wait group and stops watchDeadline,
+// exactly once, whichever of RecvMsg's error or ctx being done came first.
+func (s *drainingClientStream) finish() {
+	s.doneOnce.Do(func() {
+		s.done()
+		close(s.stopDeadlineWatch)
+	})
+}
+
+// watchDeadline half-closes the stream once @ctx's deadline fires, instead
+// of leaving it to linger until the static opt.Timeout would have applied.
+func (s *drainingClientStream) watchDeadline(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		_ = s.ClientStream.CloseSend()
+	case <-s.stopDeadlineWatch:
+	}
+}
+
+// withServiceRoutingHeaders attaches tri-service-group/tri-service-version to
+// the outgoing context so the server can route to the matching registered
+// service instance when an interface has more than one group/version
+// implementation. Per-call values injected via CallGroupKey/CallVersionKey
+// take precedence over opt.HeaderGroup/opt.HeaderAppVersion.
+func (t *TripleClient) withServiceRoutingHeaders(ctx context.Context) context.Context {
+	group := t.opt.HeaderGroup
+	if v, ok := ctx.Value(CallGroupKey).(string); ok && v != "" {
+		group = v
+	}
+	version := t.opt.HeaderAppVersion
+	if v, ok := ctx.Value(CallVersionKey).(string); ok && v != "" {
+		version = v
+	}
+	if group == "" && version == "" {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	if group != "" {
+		md.Set(triServiceGroupHeader, group)
+	}
+	if version != "" {
+		md.Set(triServiceVersionHeader, version)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// grpcTimeoutHeader is the standard gRPC header carrying the caller's
+// remaining deadline, see https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md.
+const grpcTimeoutHeader = "grpc-timeout"
+
+// withOutgoingMetadata layers the tri-service-group/tri-service-version
+// routing headers and, when @ctx carries a deadline, a grpc-timeout header
+// derived from the time remaining, onto the outgoing context.
+func (t *TripleClient) withOutgoingMetadata(ctx context.Context) context.Context {
+	ctx = t.withServiceRoutingHeaders(ctx)
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	md.Set(grpcTimeoutHeader, encodeGRPCTimeout(time.Until(deadline)))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// encodeGRPCTimeout formats @d as a gRPC timeout value: up to 8 digits
+// followed by a unit (n/u/m/S/M/H), picking the finest unit whose value
+// still fits in 8 digits so as little precision as possible is lost, per
+// the HTTP/2 transport spec.
+func encodeGRPCTimeout(d time.Duration) string {
+	if d <= 0 {
+		return "0n"
+	}
+	const maxDigits = 8
+	units := []struct {
+		suffix string
+		unit   time.Duration
+	}{
+		{"n", time.Nanosecond},
+		{"u", time.Microsecond},
+		{"m", time.Millisecond},
+		{"S", time.Second},
+		{"M", time.Minute},
+		{"H", time.Hour},
+	}
+	for _, u := range units {
+		v := d / u.unit
+		if v < 1e8 && len(strconv.FormatInt(int64(v), 10)) <= maxDigits {
+			return strconv.FormatInt(int64(v), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(d/time.Hour), 10) + "H"
 }
 
 // Close destroy http controller and return
 func (t *TripleClient) Close() {
 	t.opt.Logger.Debug("Triple Client Is closing")
+	t.drain.Close()
 	t.h2Controller.Destroy()
 }
 
+// Shutdown stops TripleClient from accepting new unary/stream invocations,
+// waits for in-flight UnaryInvoke calls and active streams to finish or
+// @ctx's deadline (falling back to opt.DrainTimeout when @ctx carries none)
+// to elapse, and only then tears down the underlying HTTP/2 connection.
+func (t *TripleClient) Shutdown(ctx context.Context) error {
+	t.once.Do(func() {
+		t.drain.Close()
+	})
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.opt.DrainTimeout)
+		defer cancel()
+	}
+
+	if !t.drain.Wait(ctx) {
+		t.opt.Logger.Errorf("triple client shutdown: drain deadline exceeded, closing with in-flight calls still active")
+	}
+
+	t.h2Controller.Destroy()
+	return nil
+}
+
 // IsAvailable returns if triple client is available
 func (t *TripleClient) IsAvailable() bool {
 	return t.h2Controller.IsAvailable()
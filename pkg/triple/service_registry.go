@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"sync"
+)
+
+import (
+	"google.golang.org/grpc/metadata"
+)
+
+// serviceKey identifies one registered implementation of an interface.
+type serviceKey struct {
+	interfaceKey string
+	group        string
+	version      string
+}
+
+// ServiceRegistry resolves an incoming request for an interface plus its
+// tri-service-group/tri-service-version headers to the matching registered
+// implementation, falling back to the default (group="", version="")
+// registration when no exact match exists.
+//
+// NOT WIRED IN: nothing in this checkout calls Register or Resolve outside
+// this file's own tests. The request this was written for asks for
+// server-side dispatch to actually route by group/version, and that half is
+// not delivered -- only the client side (withServiceRoutingHeaders attaching
+// the headers) is. Wiring Register/Resolve into the server dispatch path
+// requires the h2 controller's request-handling loop, which is not part of
+// this checkout. Do not treat this type's existence as closing that
+// requirement; it is unused scaffolding until that dispatch loop exists
+// here and calls it.
+type ServiceRegistry struct {
+	mu       sync.RWMutex
+	services map[serviceKey]interface{}
+}
+
+// NewServiceRegistry creates an empty ServiceRegistry.
+func NewServiceRegistry() *ServiceRegistry {
+	return &ServiceRegistry{services: make(map[serviceKey]interface{})}
+}
+
+// Register adds @impl as the implementation of @interfaceKey for the given
+// @group/@version. Pass "" for either to register the default
+// implementation used when a request carries no routing headers.
+func (r *ServiceRegistry) Register(interfaceKey, group, version string, impl interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[serviceKey{interfaceKey, group, version}] = impl
+}
+
+// Resolve returns the implementation registered for @interfaceKey under the
+// exact @group/@version, falling back to the default ("", "") registration
+// when there is no exact match. The second return value is false when
+// neither exists.
+func (r *ServiceRegistry) Resolve(interfaceKey, group, version string) (interface{}, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if impl, ok := r.services[serviceKey{interfaceKey, group, version}]; ok {
+		return impl, true
+	}
+	impl, ok := r.services[serviceKey{interfaceKey, "", ""}]
+	return impl, ok
+}
+
+// RoutingHeadersFromIncoming reads tri-service-group/tri-service-version off
+// an incoming request's metadata, mirroring how withServiceRoutingHeaders
+// sets them on the client side.
+func RoutingHeadersFromIncoming(md metadata.MD) (group, version string) {
+	if vs := md.Get(triServiceGroupHeader); len(vs) > 0 {
+		group = vs[0]
+	}
+	if vs := md.Get(triServiceVersionHeader); len(vs) > 0 {
+		version = vs[0]
+	}
+	return group, version
+}
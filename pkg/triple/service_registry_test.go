@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package triple
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceRegistryResolveExactMatch(t *testing.T) {
+	r := NewServiceRegistry()
+	defaultImpl := "default-impl"
+	groupAImpl := "groupA-impl"
+	r.Register("com.foo.IGreeter", "", "", defaultImpl)
+	r.Register("com.foo.IGreeter", "groupA", "1.0.0", groupAImpl)
+
+	impl, ok := r.Resolve("com.foo.IGreeter", "groupA", "1.0.0")
+	assert.True(t, ok)
+	assert.Equal(t, groupAImpl, impl)
+}
+
+func TestServiceRegistryResolveFallsBackToDefault(t *testing.T) {
+	r := NewServiceRegistry()
+	defaultImpl := "default-impl"
+	r.Register("com.foo.IGreeter", "", "", defaultImpl)
+
+	impl, ok := r.Resolve("com.foo.IGreeter", "groupB", "2.0.0")
+	assert.True(t, ok)
+	assert.Equal(t, defaultImpl, impl)
+}
+
+func TestServiceRegistryResolveUnknownInterface(t *testing.T) {
+	r := NewServiceRegistry()
+	_, ok := r.Resolve("com.foo.Unknown", "", "")
+	assert.False(t, ok)
+}